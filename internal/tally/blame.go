@@ -0,0 +1,123 @@
+package tally
+
+import (
+	"fmt"
+
+	"github.com/sinclairtarget/git-who/internal/git"
+)
+
+// Default size of the blame worker pool used by SurvivingLinesMode. Blame
+// is CPU-bound per file, so this roughly tracks typical core counts rather
+// than I/O concurrency.
+const defaultBlameWorkers = 8
+
+// Lines in a single path currently attributed to each author, keyed the
+// same way every other tally in this package is keyed: by opts.Key, not
+// necessarily by email.
+type blameTally map[string]int
+
+// Runs `git blame` over every path present in the worktree and returns,
+// for each path, how many of its current lines belong to each author.
+//
+// Paths are handed out to a pool of workers so several files are blamed
+// concurrently rather than one after another. Each path is still blamed
+// with its own git.BlameLines call; paths are not batched into a shared,
+// long-running git process.
+func blameSurvivingLines(
+	wtreefiles map[string]bool,
+	opts TallyOpts,
+) (map[string]blameTally, error) {
+	workers := opts.Parallelism
+	if workers <= 0 {
+		workers = defaultBlameWorkers
+	}
+
+	paths := make([]string, 0, len(wtreefiles))
+	for path, inTree := range wtreefiles {
+		if inTree {
+			paths = append(paths, path)
+		}
+	}
+
+	type job struct {
+		path string
+	}
+	type result struct {
+		path  string
+		lines blameTally
+		err   error
+	}
+
+	jobs := make(chan job)
+	results := make(chan result)
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			for j := range jobs {
+				lines, err := blameFile(j.path, opts.Key)
+				results <- result{path: j.path, lines: lines, err: err}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for _, path := range paths {
+			jobs <- job{path: path}
+		}
+	}()
+
+	byPath := make(map[string]blameTally, len(paths))
+	done := 0
+	for _, r := range collect(results, len(paths)) {
+		done++
+		if r.err != nil {
+			return nil, fmt.Errorf("error blaming %s: %w", r.path, r.err)
+		}
+
+		byPath[r.path] = r.lines
+		logger().Debug(
+			"blamed file",
+			"path", r.path,
+			"done", done,
+			"total", len(paths),
+		)
+	}
+
+	return byPath, nil
+}
+
+// Drains exactly n results from ch into a slice.
+func collect[T any](ch <-chan T, n int) []T {
+	out := make([]T, 0, n)
+	for i := 0; i < n; i++ {
+		out = append(out, <-ch)
+	}
+	return out
+}
+
+// Blames a single path and tallies up, per author, how many lines of the
+// current worktree version of the file belong to them.
+//
+// key is run over a synthetic commit built from each blamed line's author
+// identity, the same way it's run over real commits elsewhere in this
+// package, so blameTally ends up keyed consistently with every other
+// tally regardless of which identity opts.Key uses (email, name, or
+// something else).
+func blameFile(path string, key func(git.Commit) string) (blameTally, error) {
+	lines, err := git.BlameLines(path)
+	if err != nil {
+		return nil, fmt.Errorf("error running git blame on %s: %w", path, err)
+	}
+
+	tally := blameTally{}
+	for _, line := range lines {
+		author := git.Commit{
+			AuthorName:  line.AuthorName,
+			AuthorEmail: line.AuthorEmail,
+		}
+		tally[key(author)] += 1
+	}
+
+	return tally, nil
+}
@@ -0,0 +1,35 @@
+package tally
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestCollect(t *testing.T) {
+	ch := make(chan int, 3)
+	ch <- 1
+	ch <- 2
+	ch <- 3
+
+	got := collect(ch, 3)
+	sort.Ints(got)
+
+	want := []int{1, 2, 3}
+	if len(got) != len(want) {
+		t.Fatalf("collect() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("collect() = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestCollectZero(t *testing.T) {
+	ch := make(chan int)
+	got := collect(ch, 0)
+
+	if len(got) != 0 {
+		t.Errorf("collect(ch, 0) = %v, want empty slice", got)
+	}
+}
@@ -0,0 +1,124 @@
+package tally
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/sinclairtarget/git-who/internal/git"
+)
+
+// How credit for a commit is divided between its primary author and any
+// co-authors listed in "Co-authored-by:" trailers.
+type CoAuthorWeight int
+
+const (
+	// Split credit evenly across the primary author and co-authors.
+	Equal CoAuthorWeight = iota
+	// Credit every author, primary or co-author, as if they alone had
+	// made the commit.
+	FullToEach
+	// Ignore co-author trailers entirely. Only the primary author is
+	// credited. This is the behavior when CreditCoAuthors is false.
+	PrimaryOnly
+)
+
+var coAuthorTrailerPattern = regexp.MustCompile(
+	`(?i)^co-authored-by:\s*(.*?)\s*<([^<>\s]+)>\s*$`,
+)
+
+// One author's share of the credit for a single commit.
+type creditShare struct {
+	key    string
+	name   string
+	email  string
+	weight float64
+}
+
+// Returns the set of authors who should be credited for commit, along with
+// each author's weight. When opts.CreditCoAuthors is false, or the commit
+// has no "Co-authored-by:" trailers, the primary author is returned alone
+// with a weight of 1.
+func creditShares(commit git.Commit, opts TallyOpts) []creditShare {
+	primary := creditShare{
+		key:    opts.Key(commit),
+		name:   commit.AuthorName,
+		email:  commit.AuthorEmail,
+		weight: 1,
+	}
+
+	if !opts.CreditCoAuthors || opts.CoAuthorWeight == PrimaryOnly {
+		return []creditShare{primary}
+	}
+
+	coauthors := parseCoAuthorTrailers(commit.Message, opts.Key)
+	if len(coauthors) == 0 {
+		return []creditShare{primary}
+	}
+
+	shares := dedupeShares(append([]creditShare{primary}, coauthors...))
+
+	switch opts.CoAuthorWeight {
+	case FullToEach:
+		for i := range shares {
+			shares[i].weight = 1
+		}
+	default: // Equal
+		weight := 1 / float64(len(shares))
+		for i := range shares {
+			shares[i].weight = weight
+		}
+	}
+
+	return shares
+}
+
+// Parses "Co-authored-by: Name <email>" trailers out of a commit message,
+// per the convention used by `git commit --trailer` and GitHub.
+//
+// Co-authors are keyed the same way blame.go keys blamed lines: by building
+// a synthetic git.Commit out of the name/email we parsed and running key
+// against it, so a co-author collapses to the same key as their primary-author
+// commits regardless of what opts.Key actually keys on.
+func parseCoAuthorTrailers(
+	message string,
+	key func(git.Commit) string,
+) []creditShare {
+	var shares []creditShare
+
+	for _, line := range strings.Split(message, "\n") {
+		matches := coAuthorTrailerPattern.FindStringSubmatch(strings.TrimSpace(line))
+		if matches == nil {
+			continue
+		}
+
+		name := matches[1]
+		email := matches[2]
+		author := git.Commit{AuthorName: name, AuthorEmail: email}
+		shares = append(shares, creditShare{
+			key:   key(author),
+			name:  name,
+			email: email,
+		})
+	}
+
+	return shares
+}
+
+// Merges shares that share a key (e.g. a co-author who is also credited as
+// the primary author), summing their weight.
+func dedupeShares(shares []creditShare) []creditShare {
+	indexByKey := map[string]int{}
+	deduped := make([]creditShare, 0, len(shares))
+
+	for _, share := range shares {
+		if i, ok := indexByKey[share.key]; ok {
+			deduped[i].weight += share.weight
+			continue
+		}
+
+		indexByKey[share.key] = len(deduped)
+		deduped = append(deduped, share)
+	}
+
+	return deduped
+}
@@ -0,0 +1,132 @@
+package tally
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sinclairtarget/git-who/internal/git"
+)
+
+func keyByEmail(c git.Commit) string {
+	return c.AuthorEmail
+}
+
+func TestParseCoAuthorTrailers(t *testing.T) {
+	message := "Fix the thing\n\n" +
+		"Co-authored-by: Alice <alice@example.com>\n" +
+		"Co-authored-by: Bob Smith <bob@example.com>\n" +
+		"Not-a-trailer: whatever\n"
+
+	got := parseCoAuthorTrailers(message, keyByEmail)
+
+	want := []creditShare{
+		{key: "alice@example.com", name: "Alice", email: "alice@example.com"},
+		{key: "bob@example.com", name: "Bob Smith", email: "bob@example.com"},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseCoAuthorTrailers() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseCoAuthorTrailersUsesKeyFunc(t *testing.T) {
+	// A key func that ignores email entirely should still be used to key
+	// co-authors, the same way blame.go keys blamed lines by running
+	// opts.Key against a synthetic commit.
+	keyByName := func(c git.Commit) string {
+		return c.AuthorName
+	}
+
+	message := "Co-authored-by: Alice <alice@example.com>\n"
+	got := parseCoAuthorTrailers(message, keyByName)
+
+	if len(got) != 1 || got[0].key != "Alice" {
+		t.Errorf("parseCoAuthorTrailers() = %+v, want key \"Alice\"", got)
+	}
+}
+
+func TestDedupeShares(t *testing.T) {
+	shares := []creditShare{
+		{key: "alice@example.com", name: "Alice", weight: 1},
+		{key: "bob@example.com", name: "Bob", weight: 1},
+		{key: "alice@example.com", name: "Alice", weight: 1},
+	}
+
+	got := dedupeShares(shares)
+
+	want := []creditShare{
+		{key: "alice@example.com", name: "Alice", weight: 2},
+		{key: "bob@example.com", name: "Bob", weight: 1},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("dedupeShares() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCreditShares(t *testing.T) {
+	commit := git.Commit{
+		AuthorName:  "Alice",
+		AuthorEmail: "alice@example.com",
+		Message: "Fix the thing\n\n" +
+			"Co-authored-by: Bob Smith <bob@example.com>\n",
+	}
+
+	tests := []struct {
+		name string
+		opts TallyOpts
+		want []creditShare
+	}{
+		{
+			name: "co-author credit disabled",
+			opts: TallyOpts{Key: keyByEmail, CreditCoAuthors: false},
+			want: []creditShare{
+				{key: "alice@example.com", name: "Alice", email: "alice@example.com", weight: 1},
+			},
+		},
+		{
+			name: "primary only weight, co-author credit enabled",
+			opts: TallyOpts{
+				Key:             keyByEmail,
+				CreditCoAuthors: true,
+				CoAuthorWeight:  PrimaryOnly,
+			},
+			want: []creditShare{
+				{key: "alice@example.com", name: "Alice", email: "alice@example.com", weight: 1},
+			},
+		},
+		{
+			name: "full credit to each",
+			opts: TallyOpts{
+				Key:             keyByEmail,
+				CreditCoAuthors: true,
+				CoAuthorWeight:  FullToEach,
+			},
+			want: []creditShare{
+				{key: "alice@example.com", name: "Alice", email: "alice@example.com", weight: 1},
+				{key: "bob@example.com", name: "Bob Smith", email: "bob@example.com", weight: 1},
+			},
+		},
+		{
+			name: "equal split",
+			opts: TallyOpts{
+				Key:             keyByEmail,
+				CreditCoAuthors: true,
+				CoAuthorWeight:  Equal,
+			},
+			want: []creditShare{
+				{key: "alice@example.com", name: "Alice", email: "alice@example.com", weight: 0.5},
+				{key: "bob@example.com", name: "Bob Smith", email: "bob@example.com", weight: 0.5},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := creditShares(commit, tt.opts)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("creditShares() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,309 @@
+package tally
+
+import (
+	"iter"
+	"path"
+	"sort"
+
+	"github.com/sinclairtarget/git-who/internal/git"
+)
+
+// Default share of a file's lines/commits that must be covered before we
+// stop adding authors to its bus factor.
+const DefaultBusFactorThreshold = 0.5
+
+// How file ownership share is measured.
+type OwnershipMetric int
+
+const (
+	OwnershipByLines OwnershipMetric = iota
+	OwnershipByCommits
+)
+
+// Ownership analysis for a single file.
+type FileOwnership struct {
+	Path       string
+	Owner      string   // Author key with the largest share of the file
+	OwnerShare float64  // That author's share of the file, 0-1
+	BusFactor  []string // Smallest set of authors covering >= threshold, most-dominant first
+}
+
+// Ownership analysis rolled up to a directory prefix, e.g. "internal/git".
+type DirOwnership struct {
+	Prefix    string
+	BusFactor []string
+}
+
+// A full ownership / bus-factor report over a tree of files.
+type Ownership struct {
+	Files         map[string]FileOwnership
+	Dirs          map[string]DirOwnership
+	RepoBusFactor []string
+}
+
+// Builds an ownership report from already-tallied per-author, per-path
+// data (the same AuthorPaths the diff-based tally pipeline produces).
+//
+// For each file in wtreefiles, authors are ranked by their share of that
+// file under metric, and we compute a bus factor: the smallest set of
+// authors whose combined share meets threshold. Bus factors are also
+// rolled up by directory prefix (so callers can ask "who is the bus
+// factor for internal/git/?"), and a repo-level bus factor is computed by
+// treating files as weighted nodes and greedily solving set cover: at each
+// step, pick whichever author dominates the largest remaining weighted set
+// of files, until threshold coverage of the repo is reached.
+func OwnershipReport(
+	authors map[string]AuthorPaths,
+	wtreefiles map[string]bool,
+	metric OwnershipMetric,
+	threshold float64,
+) Ownership {
+	if threshold <= 0 {
+		threshold = DefaultBusFactorThreshold
+	}
+
+	// path -> author key -> weight (lines added or commits, per metric)
+	weightsByPath := map[string]map[string]int{}
+	fileWeight := map[string]int{}
+
+	for authorKey, authorPaths := range authors {
+		for path, pathTally := range authorPaths.paths {
+			if !wtreefiles[path] {
+				continue
+			}
+
+			weight := ownershipWeight(pathTally, metric)
+			if weight == 0 {
+				continue
+			}
+
+			byAuthor, ok := weightsByPath[path]
+			if !ok {
+				byAuthor = map[string]int{}
+				weightsByPath[path] = byAuthor
+			}
+
+			byAuthor[authorKey] = weight
+			fileWeight[path] += weight
+		}
+	}
+
+	files := make(map[string]FileOwnership, len(weightsByPath))
+	dirWeights := map[string]map[string]int{} // dir prefix -> author key -> weight
+
+	for path, byAuthor := range weightsByPath {
+		ranked := rankAuthors(byAuthor)
+		total := fileWeight[path]
+
+		fo := FileOwnership{Path: path}
+		if len(ranked) > 0 {
+			fo.Owner = ranked[0].key
+			fo.OwnerShare = float64(ranked[0].weight) / float64(total)
+		}
+		fo.BusFactor = busFactorCover(ranked, total, threshold)
+		files[path] = fo
+
+		for _, dir := range dirPrefixes(path) {
+			dirByAuthor, ok := dirWeights[dir]
+			if !ok {
+				dirByAuthor = map[string]int{}
+				dirWeights[dir] = dirByAuthor
+			}
+
+			for author, weight := range byAuthor {
+				dirByAuthor[author] += weight
+			}
+		}
+	}
+
+	dirs := make(map[string]DirOwnership, len(dirWeights))
+	for dir, byAuthor := range dirWeights {
+		total := 0
+		for _, weight := range byAuthor {
+			total += weight
+		}
+
+		dirs[dir] = DirOwnership{
+			Prefix:    dir,
+			BusFactor: busFactorCover(rankAuthors(byAuthor), total, threshold),
+		}
+	}
+
+	return Ownership{
+		Files:         files,
+		Dirs:          dirs,
+		RepoBusFactor: repoBusFactor(weightsByPath, fileWeight, threshold),
+	}
+}
+
+// Concurrent pipeline that produces an ownership report from the same
+// per-commit diffs TallyCommitsDiffApplyMerge uses, so callers don't need
+// a second walk of the log just to compute ownership.
+func TallyCommitsOwnershipApplyMerge(
+	wtreeset map[string]bool,
+	metric OwnershipMetric,
+	threshold float64,
+	opts TallyOpts,
+) (
+	TallyFunc[map[string]AuthorPaths],
+	MergeFunc[map[string]AuthorPaths],
+	FinalizeFunc[map[string]AuthorPaths, Ownership],
+) {
+	diffOpts := opts
+	diffOpts.Mode = LinesMode
+
+	apply := func(commits iter.Seq2[git.Commit, error]) (
+		map[string]AuthorPaths,
+		error,
+	) {
+		return tallyByPaths(commits, wtreeset, diffOpts)
+	}
+
+	merge := func(a, b map[string]AuthorPaths) map[string]AuthorPaths {
+		union := b
+		for key, aAuthor := range a {
+			bAuthor, ok := b[key]
+			if ok {
+				aAuthor = aAuthor.Union(bAuthor)
+			}
+			union[key] = aAuthor
+		}
+
+		return union
+	}
+
+	finalize := func(authors map[string]AuthorPaths) Ownership {
+		return OwnershipReport(authors, wtreeset, metric, threshold)
+	}
+
+	return apply, merge, finalize
+}
+
+func ownershipWeight(t intermediateTally, metric OwnershipMetric) int {
+	switch metric {
+	case OwnershipByCommits:
+		return t.Commits()
+	default: // OwnershipByLines
+		return t.added
+	}
+}
+
+type rankedAuthor struct {
+	key    string
+	weight int
+}
+
+// Ranks authors by descending weight, breaking ties by key for
+// determinism.
+func rankAuthors(byAuthor map[string]int) []rankedAuthor {
+	ranked := make([]rankedAuthor, 0, len(byAuthor))
+	for author, weight := range byAuthor {
+		ranked = append(ranked, rankedAuthor{key: author, weight: weight})
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].weight != ranked[j].weight {
+			return ranked[i].weight > ranked[j].weight
+		}
+		return ranked[i].key < ranked[j].key
+	})
+
+	return ranked
+}
+
+// Walks down authors already sorted by descending weight, adding them to
+// the bus factor until their combined share of total meets threshold.
+func busFactorCover(ranked []rankedAuthor, total int, threshold float64) []string {
+	if total == 0 {
+		return nil
+	}
+
+	var covered int
+	var factor []string
+	for _, a := range ranked {
+		factor = append(factor, a.key)
+		covered += a.weight
+
+		if float64(covered)/float64(total) >= threshold {
+			break
+		}
+	}
+
+	return factor
+}
+
+// Returns every directory prefix of p, e.g. "internal/git/blame.go" ->
+// ["internal/git", "internal"].
+func dirPrefixes(p string) []string {
+	dir := path.Dir(p)
+	if dir == "." {
+		return nil
+	}
+
+	var prefixes []string
+	for dir != "." {
+		prefixes = append(prefixes, dir)
+
+		parent := path.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	return prefixes
+}
+
+// Computes a repo-level bus factor via greedy set cover: repeatedly pick
+// whichever author dominates the largest remaining weighted set of files,
+// until the files they dominate cover threshold of the repo's total
+// weight.
+func repoBusFactor(
+	weightsByPath map[string]map[string]int,
+	fileWeight map[string]int,
+	threshold float64,
+) []string {
+	totalWeight := 0
+	for _, weight := range fileWeight {
+		totalWeight += weight
+	}
+	if totalWeight == 0 {
+		return nil
+	}
+
+	remaining := make(map[string]int, len(fileWeight))
+	for path, weight := range fileWeight {
+		remaining[path] = weight
+	}
+
+	// path -> dominant author for that path, fixed up front since
+	// dominance doesn't change as we remove covered files.
+	dominantByPath := make(map[string]string, len(weightsByPath))
+	for path, byAuthor := range weightsByPath {
+		if ranked := rankAuthors(byAuthor); len(ranked) > 0 {
+			dominantByPath[path] = ranked[0].key
+		}
+	}
+
+	var factor []string
+	var covered int
+
+	for len(remaining) > 0 && float64(covered)/float64(totalWeight) < threshold {
+		coverage := map[string]int{}
+		for path, weight := range remaining {
+			coverage[dominantByPath[path]] += weight
+		}
+
+		best := rankAuthors(coverage)[0]
+		factor = append(factor, best.key)
+		covered += best.weight
+
+		for path := range remaining {
+			if dominantByPath[path] == best.key {
+				delete(remaining, path)
+			}
+		}
+	}
+
+	return factor
+}
@@ -0,0 +1,104 @@
+package tally
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBusFactorCover(t *testing.T) {
+	ranked := []rankedAuthor{
+		{key: "alice", weight: 60},
+		{key: "bob", weight: 30},
+		{key: "carol", weight: 10},
+	}
+
+	tests := []struct {
+		name      string
+		total     int
+		threshold float64
+		want      []string
+	}{
+		{
+			name:      "dominant author alone covers threshold",
+			total:     100,
+			threshold: 0.5,
+			want:      []string{"alice"},
+		},
+		{
+			name:      "needs a second author to reach threshold",
+			total:     100,
+			threshold: 0.8,
+			want:      []string{"alice", "bob"},
+		},
+		{
+			name:      "needs every author to reach threshold",
+			total:     100,
+			threshold: 1,
+			want:      []string{"alice", "bob", "carol"},
+		},
+		{
+			name:      "zero total weight covers nothing",
+			total:     0,
+			threshold: 0.5,
+			want:      nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := busFactorCover(ranked, tt.total, tt.threshold)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("busFactorCover() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepoBusFactor(t *testing.T) {
+	// alice dominates a.go and b.go (21 of the repo's 36 total weight);
+	// bob dominates c.go alone. A 0.5 threshold is covered by alice alone;
+	// 0.8 needs bob too.
+	weightsByPath := map[string]map[string]int{
+		"a.go": {"alice": 10, "bob": 1},
+		"b.go": {"alice": 10},
+		"c.go": {"bob": 15},
+	}
+	fileWeight := map[string]int{
+		"a.go": 11,
+		"b.go": 10,
+		"c.go": 15,
+	}
+
+	got := repoBusFactor(weightsByPath, fileWeight, 0.5)
+	want := []string{"alice"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("repoBusFactor(threshold=0.5) = %v, want %v", got, want)
+	}
+
+	got = repoBusFactor(weightsByPath, fileWeight, 0.8)
+	want = []string{"alice", "bob"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("repoBusFactor(threshold=0.8) = %v, want %v", got, want)
+	}
+
+	if got := repoBusFactor(nil, nil, 0.5); got != nil {
+		t.Errorf("repoBusFactor() with no files = %v, want nil", got)
+	}
+}
+
+func TestDirPrefixes(t *testing.T) {
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{path: "internal/git/blame.go", want: []string{"internal/git", "internal"}},
+		{path: "main.go", want: nil},
+	}
+
+	for _, tt := range tests {
+		got := dirPrefixes(tt.path)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("dirPrefixes(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
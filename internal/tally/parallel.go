@@ -0,0 +1,323 @@
+package tally
+
+import (
+	"fmt"
+	"hash/fnv"
+	"iter"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sinclairtarget/git-who/internal/git"
+	"github.com/sinclairtarget/git-who/internal/timeutils"
+)
+
+// One file touched by one commit, as seen by the diff-based tally path.
+// tallyByPaths streams these into the sharded tallier below instead of
+// accumulating into a single shared map.
+type PathDiff struct {
+	AuthorKey  string
+	CommitHash string
+	Path       string
+	Added      int
+	Removed    int
+	Date       time.Time
+}
+
+const (
+	// Channel depth at which a shard is considered "hot".
+	shardBacklogHot = 32
+	// Consecutive empty polls before an idle shard is considered "cold"
+	// and eligible to take over a hot shard's not-yet-seen paths.
+	shardColdStreak = 8
+)
+
+// Tallies path diffs over a pool of parallelism workers. Each worker owns
+// a disjoint slice of the path keyspace, sharded by hash(path) % N, so
+// workers never contend over the same map and no locking is needed on the
+// per-worker tallies themselves.
+//
+// A coordinator goroutine watches each shard's channel backlog and, when
+// one shard sits idle while another stays hot, redirects the hot shard's
+// paths that haven't been dispatched yet over to the idle one -- work stealing over
+// the keyspace rather than over in-flight state, since a path's
+// accumulated tally can't move once it exists without taking a lock.
+//
+// The final merge is a simple union: since every path is pinned to
+// exactly one shard for the lifetime of the run, no two shards ever
+// produce a tally for the same (author, path) pair.
+func tallyPathsSharded(
+	diffs iter.Seq[PathDiff],
+	parallelism int,
+	tz *time.Location,
+) map[string]map[string]intermediateTally { // author key -> path -> tally
+	n := parallelism
+	if n <= 0 {
+		n = 1
+	}
+
+	shards := make([]chan PathDiff, n)
+	backlog := make([]atomic.Int64, n)
+	for i := range shards {
+		shards[i] = make(chan PathDiff, shardBacklogHot*4)
+	}
+
+	assignment := newShardAssignment(n)
+	results := make([]map[string]map[string]intermediateTally, n)
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = runShardWorker(shards[i], &backlog[i], tz)
+		}(i)
+	}
+
+	done := make(chan struct{})
+	go coordinateShards(backlog, assignment, done)
+
+	for diff := range diffs {
+		shard := assignment.shardFor(diff.Path)
+		backlog[shard].Add(1)
+		shards[shard] <- diff
+	}
+
+	for _, ch := range shards {
+		close(ch)
+	}
+
+	wg.Wait()
+	close(done)
+
+	return unionShardResults(results)
+}
+
+func runShardWorker(
+	in <-chan PathDiff,
+	backlog *atomic.Int64,
+	tz *time.Location,
+) map[string]map[string]intermediateTally {
+	paths := map[string]map[string]intermediateTally{}
+
+	for diff := range in {
+		backlog.Add(-1)
+
+		authorPaths, ok := paths[diff.AuthorKey]
+		if !ok {
+			authorPaths = map[string]intermediateTally{}
+			paths[diff.AuthorKey] = authorPaths
+		}
+
+		t := authorPaths[diff.Path]
+		if t.commitset == nil {
+			t = newTally(1)
+		}
+
+		t.commitset[diff.CommitHash] = true
+		t.added += diff.Added
+		t.removed += diff.Removed
+		t.days[dayKey(diff.Date, tz)] = true
+		t.firstCommitTime = minTime(diff.Date, t.firstCommitTime)
+		t.lastCommitTime = timeutils.Max(diff.Date, t.lastCommitTime)
+
+		authorPaths[diff.Path] = t
+	}
+
+	return paths
+}
+
+// Shards are disjoint by path, so this is a plain union with no need to
+// call intermediateTally.Add across shards.
+func unionShardResults(
+	results []map[string]map[string]intermediateTally,
+) map[string]map[string]intermediateTally {
+	union := map[string]map[string]intermediateTally{}
+
+	for _, shard := range results {
+		for author, paths := range shard {
+			authorPaths, ok := union[author]
+			if !ok {
+				authorPaths = map[string]intermediateTally{}
+				union[author] = authorPaths
+			}
+
+			for path, t := range paths {
+				authorPaths[path] = t
+			}
+		}
+	}
+
+	return union
+}
+
+// Assigns paths to shards, defaulting to hash(path) % n, but lets the
+// coordinator redirect paths that haven't been dispatched yet to a
+// different shard once they're "pinned" to whichever shard they were
+// first assigned.
+type shardAssignment struct {
+	n         int
+	mu        sync.Mutex
+	pinned    map[string]int // path -> shard, fixed once first dispatched
+	redirects []int          // default shard index -> current target shard
+}
+
+func newShardAssignment(n int) *shardAssignment {
+	redirects := make([]int, n)
+	for i := range redirects {
+		redirects[i] = i
+	}
+
+	return &shardAssignment{
+		n:         n,
+		pinned:    map[string]int{},
+		redirects: redirects,
+	}
+}
+
+func (a *shardAssignment) shardFor(path string) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if shard, ok := a.pinned[path]; ok {
+		return shard
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(path))
+	defaultShard := int(h.Sum32() % uint32(a.n))
+
+	shard := a.redirects[defaultShard]
+	a.pinned[path] = shard
+	return shard
+}
+
+func (a *shardAssignment) redirect(from, to int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.redirects[from] = to
+}
+
+// Polls shard backlogs and redirects the future paths of whichever shard
+// is currently hottest over to a consistently idle shard.
+func coordinateShards(
+	backlog []atomic.Int64,
+	assignment *shardAssignment,
+	done <-chan struct{},
+) {
+	coldStreak := make([]int, len(backlog))
+
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			hot, hotDepth := -1, int64(shardBacklogHot)
+			for i := range backlog {
+				if depth := backlog[i].Load(); depth >= hotDepth {
+					hot, hotDepth = i, depth
+				}
+			}
+
+			for i := range backlog {
+				if i == hot {
+					coldStreak[i] = 0
+					continue
+				}
+
+				if backlog[i].Load() == 0 {
+					coldStreak[i]++
+				} else {
+					coldStreak[i] = 0
+				}
+
+				if hot >= 0 && coldStreak[i] >= shardColdStreak {
+					assignment.redirect(hot, i)
+					coldStreak[i] = 0
+				}
+			}
+		}
+	}
+}
+
+// Backs every diff-based tally mode (lines, files, surviving lines,
+// ownership): walks commits, explodes each one into a PathDiff per
+// changed path, and tallies those diffs with
+// tallyPathsSharded so large logs fan out across opts.Parallelism workers
+// instead of being processed one path at a time on a single goroutine.
+//
+// wtreefiles is not used to filter here -- sumOverPaths (based on
+// allowOutsideWorktree) and OwnershipReport (worktree paths only) already
+// decide what to keep, and filtering twice would double that logic for
+// no benefit.
+func tallyByPaths(
+	commits iter.Seq2[git.Commit, error],
+	wtreefiles map[string]bool,
+	opts TallyOpts,
+) (map[string]AuthorPaths, error) {
+	names := map[string]creditShare{}
+	var iterErr error
+
+	diffs := func(yield func(PathDiff) bool) {
+		for commit, err := range commits {
+			if err != nil {
+				iterErr = fmt.Errorf("error iterating commits: %w", err)
+				return
+			}
+
+			for _, share := range creditShares(commit, opts) {
+				if share.weight == 0 {
+					continue
+				}
+
+				if _, ok := names[share.key]; !ok {
+					names[share.key] = share
+				}
+
+				for _, fd := range commit.FileDiffs {
+					diff := PathDiff{
+						AuthorKey:  share.key,
+						CommitHash: commit.Hash,
+						Path:       fd.Path,
+						Added:      weightLines(fd.LinesAdded, share.weight),
+						Removed:    weightLines(fd.LinesRemoved, share.weight),
+						Date:       commit.Date,
+					}
+
+					if !yield(diff) {
+						return
+					}
+				}
+			}
+		}
+	}
+
+	sharded := tallyPathsSharded(diffs, opts.Parallelism, opts.TZ)
+	if iterErr != nil {
+		return nil, iterErr
+	}
+
+	authors := make(map[string]AuthorPaths, len(sharded))
+	for key, paths := range sharded {
+		share := names[key]
+		authors[key] = AuthorPaths{
+			name:  share.name,
+			email: share.email,
+			paths: paths,
+		}
+	}
+
+	return authors, nil
+}
+
+// Splits a diff's line count across a co-author's share of the credit for
+// that commit. Rounds to the nearest line rather than truncating so that,
+// e.g., two equal co-authors of a one-line change aren't both rounded
+// down to zero.
+func weightLines(n int, weight float64) int {
+	return int(math.Round(float64(n) * weight))
+}
@@ -0,0 +1,230 @@
+package tally
+
+import (
+	"fmt"
+	"iter"
+	"time"
+
+	"github.com/sinclairtarget/git-who/internal/git"
+	"github.com/sinclairtarget/git-who/internal/timeutils"
+)
+
+// A calendar granularity to bucket commits by.
+type BucketUnit int
+
+const (
+	BucketDay BucketUnit = iota
+	BucketWeek
+	BucketMonth
+	BucketQuarter
+	BucketYear
+)
+
+// Describes how to carve up commit history into time buckets, e.g. "every
+// two weeks" or "every quarter."
+type BucketSpec struct {
+	Unit BucketUnit
+	N    int // Bucket every N units. Defaults to 1 if <= 0.
+}
+
+// Reference points N-day and N-week buckets are counted from, so buckets
+// stay contiguous across month/year boundaries instead of resetting on the
+// 1st of the month (days) or restarting the week count every Jan 1 (weeks).
+// dayEpoch is arbitrary; weekEpoch is a Monday so week buckets still start
+// on Monday.
+var (
+	dayEpoch  = time.Date(1970, time.January, 1, 0, 0, 0, 0, time.UTC)
+	weekEpoch = time.Date(1970, time.January, 5, 0, 0, 0, 0, time.UTC)
+)
+
+// Returns the start of the bucket that t falls into.
+func (spec BucketSpec) Start(t time.Time) time.Time {
+	n := spec.N
+	if n <= 0 {
+		n = 1
+	}
+
+	t = t.UTC()
+	switch spec.Unit {
+	case BucketDay:
+		day := t.Truncate(24 * time.Hour)
+		daysSinceEpoch := int(day.Sub(dayEpoch).Hours() / 24)
+		bucketIdx := daysSinceEpoch - floorMod(daysSinceEpoch, n)
+		return dayEpoch.AddDate(0, 0, bucketIdx)
+	case BucketWeek:
+		day := t.Truncate(24 * time.Hour)
+		// Weeks start on Monday.
+		offset := (int(day.Weekday()) + 6) % 7
+		weekStart := day.AddDate(0, 0, -offset)
+
+		weeksSinceEpoch := int(weekStart.Sub(weekEpoch).Hours() / (24 * 7))
+		bucketIdx := weeksSinceEpoch - floorMod(weeksSinceEpoch, n)
+		return weekEpoch.AddDate(0, 0, 7*bucketIdx)
+	case BucketMonth:
+		// Counted from year 0 so N-month buckets stay contiguous across
+		// year boundaries and agree with Next.
+		months := t.Year()*12 + int(t.Month()) - 1
+		bucketIdx := months - floorMod(months, n)
+		return time.Date(bucketIdx/12, time.Month(bucketIdx%12+1), 1, 0, 0, 0, 0, time.UTC)
+	case BucketQuarter:
+		quarters := t.Year()*4 + (int(t.Month())-1)/3
+		bucketIdx := quarters - floorMod(quarters, n)
+		return time.Date(bucketIdx/4, time.Month((bucketIdx%4)*3+1), 1, 0, 0, 0, 0, time.UTC)
+	case BucketYear:
+		year := t.Year() - (t.Year() % n)
+		return time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		panic("unrecognized bucket unit in switch statement")
+	}
+}
+
+// Like a % n, but always returns a value in [0, n), matching the
+// mathematical definition of modulo rather than Go's truncating %, which
+// can return negative results for negative a.
+func floorMod(a, n int) int {
+	m := a % n
+	if m < 0 {
+		m += n
+	}
+	return m
+}
+
+// Returns the start of the bucket that immediately follows the bucket
+// starting at t. t is assumed to already be a bucket start (e.g. the
+// result of Start).
+func (spec BucketSpec) Next(t time.Time) time.Time {
+	n := spec.N
+	if n <= 0 {
+		n = 1
+	}
+
+	switch spec.Unit {
+	case BucketDay:
+		return t.AddDate(0, 0, n)
+	case BucketWeek:
+		return t.AddDate(0, 0, 7*n)
+	case BucketMonth:
+		return t.AddDate(0, n, 0)
+	case BucketQuarter:
+		return t.AddDate(0, 3*n, 0)
+	case BucketYear:
+		return t.AddDate(n, 0, 0)
+	default:
+		panic("unrecognized bucket unit in switch statement")
+	}
+}
+
+// Tallies commits, grouping the results into time buckets. Returns, for
+// each bucket start time, a sorted slice of per-author tallies covering
+// commits that fall within that bucket -- e.g. "who contributed what
+// during Q2 2024?"
+func TallyCommitsByRange(
+	commits iter.Seq2[git.Commit, error],
+	wtreefiles map[string]bool,
+	allowOutsideWorktree bool,
+	opts TallyOpts,
+	bucket BucketSpec,
+) (map[time.Time][]Tally, error) {
+	buckets, err := tallyCommitsByRange(commits, bucket, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return finalizeBuckets(buckets, opts), nil
+}
+
+// Concurrent pipeline for range-bucketed tallying of commits.
+func TallyCommitsRangeApplyMerge(
+	wtreeset map[string]bool,
+	allowOutsideWorktree bool,
+	opts TallyOpts,
+	bucket BucketSpec,
+) (
+	TallyFunc[map[time.Time]map[string]Tally],
+	MergeFunc[map[time.Time]map[string]Tally],
+	FinalizeFunc[map[time.Time]map[string]Tally, map[time.Time][]Tally],
+) {
+	apply := func(commits iter.Seq2[git.Commit, error]) (
+		map[time.Time]map[string]Tally,
+		error,
+	) {
+		return tallyCommitsByRange(commits, bucket, opts)
+	}
+
+	merge := func(
+		a, b map[time.Time]map[string]Tally,
+	) map[time.Time]map[string]Tally {
+		for bucketStart, aAuthors := range a {
+			bAuthors, ok := b[bucketStart]
+			if !ok {
+				b[bucketStart] = aAuthors
+				continue
+			}
+
+			for key, at := range aAuthors {
+				bt := bAuthors[key]
+				bt.AuthorName = at.AuthorName
+				bt.AuthorEmail = at.AuthorEmail
+				bt.Commits += at.Commits
+				bt.FirstCommitTime = minTime(at.FirstCommitTime, bt.FirstCommitTime)
+				bt.LastCommitTime = timeutils.Max(at.LastCommitTime, bt.LastCommitTime)
+				bAuthors[key] = bt
+			}
+
+			b[bucketStart] = bAuthors
+		}
+
+		return b
+	}
+
+	finalize := func(buckets map[time.Time]map[string]Tally) map[time.Time][]Tally {
+		return finalizeBuckets(buckets, opts)
+	}
+
+	return apply, merge, finalize
+}
+
+func tallyCommitsByRange(
+	commits iter.Seq2[git.Commit, error],
+	bucket BucketSpec,
+	opts TallyOpts,
+) (map[time.Time]map[string]Tally, error) {
+	buckets := map[time.Time]map[string]Tally{}
+
+	for commit, err := range commits {
+		if err != nil {
+			return nil, fmt.Errorf("error iterating commits: %w", err)
+		}
+
+		bucketStart := bucket.Start(commit.Date)
+		authorTallies, ok := buckets[bucketStart]
+		if !ok {
+			authorTallies = map[string]Tally{}
+			buckets[bucketStart] = authorTallies
+		}
+
+		key := opts.Key(commit)
+		authorTally := authorTallies[key]
+		authorTally.AuthorName = commit.AuthorName
+		authorTally.AuthorEmail = commit.AuthorEmail
+		authorTally.Commits += 1
+		authorTally.FirstCommitTime = minTime(commit.Date, authorTally.FirstCommitTime)
+		authorTally.LastCommitTime = timeutils.Max(commit.Date, authorTally.LastCommitTime)
+
+		authorTallies[key] = authorTally
+	}
+
+	return buckets, nil
+}
+
+func finalizeBuckets(
+	buckets map[time.Time]map[string]Tally,
+	opts TallyOpts,
+) map[time.Time][]Tally {
+	result := make(map[time.Time][]Tally, len(buckets))
+	for bucketStart, authorTallies := range buckets {
+		result[bucketStart] = sortTallies(authorTallies, opts.Mode)
+	}
+
+	return result
+}
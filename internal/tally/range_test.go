@@ -0,0 +1,163 @@
+package tally
+
+import (
+	"testing"
+	"time"
+)
+
+func mustParse(t *testing.T, s string) time.Time {
+	t.Helper()
+	tm, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		t.Fatalf("bad test fixture time %q: %v", s, err)
+	}
+	return tm
+}
+
+func TestBucketSpecStart(t *testing.T) {
+	tests := []struct {
+		name string
+		spec BucketSpec
+		in   string
+		want string
+	}{
+		{
+			name: "day",
+			spec: BucketSpec{Unit: BucketDay},
+			in:   "2024-06-15T14:30:00Z",
+			want: "2024-06-15T00:00:00Z",
+		},
+		{
+			name: "week starts on Monday",
+			spec: BucketSpec{Unit: BucketWeek},
+			in:   "2024-06-19T14:30:00Z", // a Wednesday
+			want: "2024-06-17T00:00:00Z", // the preceding Monday
+		},
+		{
+			name: "month",
+			spec: BucketSpec{Unit: BucketMonth},
+			in:   "2024-06-15T14:30:00Z",
+			want: "2024-06-01T00:00:00Z",
+		},
+		{
+			name: "quarter",
+			spec: BucketSpec{Unit: BucketQuarter},
+			in:   "2024-08-01T00:00:00Z",
+			want: "2024-07-01T00:00:00Z",
+		},
+		{
+			name: "year",
+			spec: BucketSpec{Unit: BucketYear},
+			in:   "2024-08-01T00:00:00Z",
+			want: "2024-01-01T00:00:00Z",
+		},
+		{
+			name: "every two months",
+			spec: BucketSpec{Unit: BucketMonth, N: 2},
+			in:   "2024-06-15T00:00:00Z",
+			want: "2024-05-01T00:00:00Z",
+		},
+		{
+			name: "every three days",
+			spec: BucketSpec{Unit: BucketDay, N: 3},
+			in:   "2026-07-31T12:00:00Z",
+			want: "2026-07-30T00:00:00Z",
+		},
+		{
+			name: "every three days, bucket adjacent to the above",
+			spec: BucketSpec{Unit: BucketDay, N: 3},
+			in:   "2026-08-01T00:00:00Z",
+			want: "2026-07-30T00:00:00Z",
+		},
+		{
+			name: "every two weeks",
+			spec: BucketSpec{Unit: BucketWeek, N: 2},
+			in:   "2024-06-10T00:00:00Z",
+			want: "2024-06-10T00:00:00Z",
+		},
+		{
+			name: "every two weeks, the following Monday falls in the same bucket",
+			spec: BucketSpec{Unit: BucketWeek, N: 2},
+			in:   "2024-06-17T00:00:00Z",
+			want: "2024-06-10T00:00:00Z",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.spec.Start(mustParse(t, tt.in))
+			want := mustParse(t, tt.want)
+			if !got.Equal(want) {
+				t.Errorf("Start(%s) = %s, want %s", tt.in, got, want)
+			}
+		})
+	}
+}
+
+func TestBucketSpecNext(t *testing.T) {
+	tests := []struct {
+		name string
+		spec BucketSpec
+		in   string
+		want string
+	}{
+		{
+			name: "day",
+			spec: BucketSpec{Unit: BucketDay},
+			in:   "2024-06-15T00:00:00Z",
+			want: "2024-06-16T00:00:00Z",
+		},
+		{
+			name: "week",
+			spec: BucketSpec{Unit: BucketWeek},
+			in:   "2024-06-17T00:00:00Z",
+			want: "2024-06-24T00:00:00Z",
+		},
+		{
+			name: "month",
+			spec: BucketSpec{Unit: BucketMonth},
+			in:   "2024-06-01T00:00:00Z",
+			want: "2024-07-01T00:00:00Z",
+		},
+		{
+			name: "quarter",
+			spec: BucketSpec{Unit: BucketQuarter},
+			in:   "2024-07-01T00:00:00Z",
+			want: "2024-10-01T00:00:00Z",
+		},
+		{
+			name: "year",
+			spec: BucketSpec{Unit: BucketYear},
+			in:   "2024-01-01T00:00:00Z",
+			want: "2025-01-01T00:00:00Z",
+		},
+		{
+			name: "every two months",
+			spec: BucketSpec{Unit: BucketMonth, N: 2},
+			in:   "2024-05-01T00:00:00Z",
+			want: "2024-07-01T00:00:00Z",
+		},
+		{
+			name: "every three days",
+			spec: BucketSpec{Unit: BucketDay, N: 3},
+			in:   "2026-07-30T00:00:00Z",
+			want: "2026-08-02T00:00:00Z",
+		},
+		{
+			name: "every two weeks",
+			spec: BucketSpec{Unit: BucketWeek, N: 2},
+			in:   "2024-06-10T00:00:00Z",
+			want: "2024-06-24T00:00:00Z",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.spec.Next(mustParse(t, tt.in))
+			want := mustParse(t, tt.want)
+			if !got.Equal(want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.in, got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package tally
+
+import (
+	"iter"
+	"slices"
+	"time"
+
+	"github.com/sinclairtarget/git-who/internal/git"
+)
+
+const dayKeyFormat = "2006-01-02"
+
+// Truncates t to a day in tz (UTC if tz is nil) and formats it as a
+// comparable, sortable key.
+func dayKey(t time.Time, tz *time.Location) string {
+	if tz == nil {
+		tz = time.UTC
+	}
+
+	return t.In(tz).Format(dayKeyFormat)
+}
+
+// Computes ActiveDays (the size of days) and LongestStreakDays (the
+// longest run of calendar-consecutive days in days) from a set of day
+// keys produced by dayKey.
+func streakStats(days map[string]bool) (activeDays int, longestStreak int) {
+	if len(days) == 0 {
+		return 0, 0
+	}
+
+	sorted := make([]time.Time, 0, len(days))
+	for day := range days {
+		t, err := time.Parse(dayKeyFormat, day)
+		if err != nil {
+			continue
+		}
+		sorted = append(sorted, t)
+	}
+
+	slices.SortFunc(sorted, func(a, b time.Time) int {
+		return a.Compare(b)
+	})
+
+	longestStreak = 1
+	streak := 1
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].Sub(sorted[i-1]) == 24*time.Hour {
+			streak++
+		} else {
+			streak = 1
+		}
+
+		if streak > longestStreak {
+			longestStreak = streak
+		}
+	}
+
+	return len(sorted), longestStreak
+}
+
+// Returns a sparkline-ready slice of commit counts for authorKey, one
+// entry per bucket, covering every bucket from the first to the last
+// commit in commits (by any author) at the requested granularity; buckets
+// where authorKey has no commits are 0. Reuses
+// the same bucketing TallyCommitsByRange uses, so a streak/cadence report
+// and a range-stats report always agree on bucket boundaries.
+func CommitSparkline(
+	commits iter.Seq2[git.Commit, error],
+	opts TallyOpts,
+	authorKey string,
+	bucket BucketSpec,
+) ([]int, error) {
+	buckets, err := tallyCommitsByRange(commits, bucket, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(buckets) == 0 {
+		return nil, nil
+	}
+
+	counts := map[time.Time]int{}
+	var first, last time.Time
+	for bucketStart, authorTallies := range buckets {
+		if first.IsZero() || bucketStart.Before(first) {
+			first = bucketStart
+		}
+		if last.IsZero() || bucketStart.After(last) {
+			last = bucketStart
+		}
+
+		if authorTally, ok := authorTallies[authorKey]; ok {
+			counts[bucketStart] = authorTally.Commits
+		}
+	}
+
+	var sparkline []int
+	for b := first; !b.After(last); b = bucket.Next(b) {
+		sparkline = append(sparkline, counts[b])
+	}
+
+	return sparkline, nil
+}
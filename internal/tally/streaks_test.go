@@ -0,0 +1,71 @@
+package tally
+
+import "testing"
+
+func TestStreakStats(t *testing.T) {
+	tests := []struct {
+		name              string
+		days              []string
+		wantActiveDays    int
+		wantLongestStreak int
+	}{
+		{
+			name:              "no days",
+			days:              nil,
+			wantActiveDays:    0,
+			wantLongestStreak: 0,
+		},
+		{
+			name:              "single day",
+			days:              []string{"2024-06-01"},
+			wantActiveDays:    1,
+			wantLongestStreak: 1,
+		},
+		{
+			name: "one consecutive streak",
+			days: []string{
+				"2024-06-01", "2024-06-02", "2024-06-03",
+			},
+			wantActiveDays:    3,
+			wantLongestStreak: 3,
+		},
+		{
+			name: "gap breaks the streak",
+			days: []string{
+				"2024-06-01", "2024-06-02", "2024-06-10",
+			},
+			wantActiveDays:    3,
+			wantLongestStreak: 2,
+		},
+		{
+			name: "longest streak isn't the most recent",
+			days: []string{
+				"2024-06-01", "2024-06-02", "2024-06-03", "2024-06-04",
+				"2024-07-01", "2024-07-02",
+			},
+			wantActiveDays:    6,
+			wantLongestStreak: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			days := map[string]bool{}
+			for _, d := range tt.days {
+				days[d] = true
+			}
+
+			activeDays, longestStreak := streakStats(days)
+			if activeDays != tt.wantActiveDays {
+				t.Errorf("ActiveDays = %d, want %d", activeDays, tt.wantActiveDays)
+			}
+			if longestStreak != tt.wantLongestStreak {
+				t.Errorf(
+					"LongestStreakDays = %d, want %d",
+					longestStreak,
+					tt.wantLongestStreak,
+				)
+			}
+		})
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"iter"
 	"maps"
+	"math"
 	"slices"
+	"strings"
 	"time"
 
 	"github.com/sinclairtarget/git-who/internal/git"
@@ -21,32 +23,57 @@ const (
 	LinesMode
 	FilesMode
 	LastModifiedMode
+	RangeStatsMode     // Ranks authors within a time bucket; see range.go
+	SurvivingLinesMode // Ranks authors by lines they still own; see blame.go
+	StreaksMode        // Ranks authors by longest consecutive-day streak; see streaks.go
 )
 
 type TallyOpts struct {
 	Mode TallyMode
 	Key  func(c git.Commit) string // Unique ID for author
+
+	// If set, credit for a commit is split across the primary author and
+	// everyone listed in a "Co-authored-by:" trailer, instead of going
+	// entirely to the primary author. See coauthors.go.
+	CreditCoAuthors bool
+	CoAuthorWeight  CoAuthorWeight
+
+	// Number of workers used by the concurrent, diff-based tally paths
+	// (sharded path tallying, blame). Defaults to a small, fixed worker
+	// count when <= 0. See parallel.go and blame.go.
+	Parallelism int
+
+	// Timezone commit dates are truncated to a day in when computing
+	// ActiveDays / LongestStreakDays. Defaults to UTC. See streaks.go.
+	TZ *time.Location
 }
 
 // Whether we need --stat and --summary data from git log for this tally mode
 func (opts TallyOpts) IsDiffMode() bool {
-	return opts.Mode == FilesMode || opts.Mode == LinesMode
+	return opts.Mode == FilesMode ||
+		opts.Mode == LinesMode ||
+		opts.Mode == SurvivingLinesMode
 }
 
 // Metrics tallied while walking git log
 type Tally struct {
-	AuthorName     string
-	AuthorEmail    string
-	Commits        int // Num commits editing paths in tree by this author
-	LinesAdded     int // Num lines added to paths in tree by author
-	LinesRemoved   int // Num lines deleted from paths in tree by author
-	FileCount      int // Num of file paths in working dir touched by author
-	LastCommitTime time.Time
+	AuthorName        string
+	AuthorEmail       string
+	Commits           int // Num commits editing paths in tree by this author
+	LinesAdded        int // Num lines added to paths in tree by author
+	LinesRemoved      int // Num lines deleted from paths in tree by author
+	FileCount         int // Num of file paths in working dir touched by author
+	LinesSurviving    int // Num lines in the worktree today blamed on author
+	LinesChurned      int // LinesAdded minus LinesSurviving
+	ActiveDays        int // Num distinct days (in TallyOpts.TZ) author committed on
+	LongestStreakDays int // Longest run of consecutive active days
+	FirstCommitTime   time.Time
+	LastCommitTime    time.Time
 }
 
 func (t Tally) SortKey(mode TallyMode) int64 {
 	switch mode {
-	case CommitMode:
+	case CommitMode, RangeStatsMode:
 		return int64(t.Commits)
 	case FilesMode:
 		return int64(t.FileCount)
@@ -54,6 +81,10 @@ func (t Tally) SortKey(mode TallyMode) int64 {
 		return int64(t.LinesAdded + t.LinesRemoved)
 	case LastModifiedMode:
 		return t.LastCommitTime.Unix()
+	case SurvivingLinesMode:
+		return int64(t.LinesSurviving)
+	case StreaksMode:
+		return int64(t.LongestStreakDays)
 	default:
 		panic("unrecognized mode in switch statement")
 	}
@@ -69,22 +100,40 @@ func (a Tally) Compare(b Tally, mode TallyMode) int {
 		return 1
 	}
 
-	// Break ties with last edited
-	return a.LastCommitTime.Compare(b.LastCommitTime)
+	if mode == StreaksMode && a.ActiveDays != b.ActiveDays {
+		if a.ActiveDays < b.ActiveDays {
+			return -1
+		}
+		return 1
+	}
+
+	// Break ties with last edited, then fall back to author identity so
+	// that the order is fully deterministic even when two authors share
+	// both a rank and a last-commit time (common within a single range
+	// bucket).
+	if c := a.LastCommitTime.Compare(b.LastCommitTime); c != 0 {
+		return c
+	}
+
+	return strings.Compare(a.AuthorEmail, b.AuthorEmail)
 }
 
 // A tally that can be combined with other tallies
 type intermediateTally struct {
-	commitset      map[string]bool
-	added          int
-	removed        int
-	lastCommitTime time.Time
-	numTallied     int
+	commitset       map[string]bool
+	added           int
+	removed         int
+	surviving       int             // Lines blamed on this author in the current worktree
+	days            map[string]bool // Distinct days (see dayKey) author committed on this path
+	firstCommitTime time.Time
+	lastCommitTime  time.Time
+	numTallied      int
 }
 
 func newTally(numTallied int) intermediateTally {
 	return intermediateTally{
 		commitset:  map[string]bool{},
+		days:       map[string]bool{},
 		numTallied: numTallied,
 	}
 }
@@ -99,13 +148,67 @@ func (a intermediateTally) Add(b intermediateTally) intermediateTally {
 		union[commit] = true
 	}
 
+	days := a.days
+	for day := range b.days {
+		days[day] = true
+	}
+
 	return intermediateTally{
-		commitset:      union,
-		added:          a.added + b.added,
-		removed:        a.removed + b.removed,
-		lastCommitTime: timeutils.Max(a.lastCommitTime, b.lastCommitTime),
-		numTallied:     a.numTallied + b.numTallied,
+		commitset:       union,
+		added:           a.added + b.added,
+		removed:         a.removed + b.removed,
+		surviving:       a.surviving + b.surviving,
+		days:            days,
+		firstCommitTime: minTime(a.firstCommitTime, b.firstCommitTime),
+		lastCommitTime:  timeutils.Max(a.lastCommitTime, b.lastCommitTime),
+		numTallied:      a.numTallied + b.numTallied,
+	}
+}
+
+// Returns the earlier of a and b, treating the zero time as "unset" rather
+// than as the earliest possible time.
+func minTime(a, b time.Time) time.Time {
+	if a.IsZero() {
+		return b
+	}
+	if b.IsZero() {
+		return a
+	}
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+// One author's running tally for every path they've touched, as produced
+// by the diff-based tally path (tallyByPaths).
+type AuthorPaths struct {
+	name  string
+	email string
+	paths map[string]intermediateTally
+}
+
+// Combines two AuthorPaths for the same author, coming from different
+// shards of the log.
+func (a AuthorPaths) Union(b AuthorPaths) AuthorPaths {
+	name, email := a.name, a.email
+	if name == "" {
+		name = b.name
+	}
+	if email == "" {
+		email = b.email
 	}
+
+	paths := a.paths
+	for path, bt := range b.paths {
+		if at, ok := paths[path]; ok {
+			paths[path] = at.Add(bt)
+		} else {
+			paths[path] = bt
+		}
+	}
+
+	return AuthorPaths{name: name, email: email, paths: paths}
 }
 
 // Returns a slice of tallies, each one for a different author, in descending
@@ -131,7 +234,12 @@ func TallyCommits(
 	return sorted, nil
 }
 
-// Concurrent pipeline for simple tallying of commits
+// Concurrent pipeline for simple tallying of commits.
+//
+// Note StreaksMode's ActiveDays / LongestStreakDays are not meaningful
+// here: each worker only sees its own slice of the log, so streaks that
+// span a slice boundary would be undercounted if we merged them. Use the
+// sequential TallyCommits for StreaksMode.
 func TallyCommitsApplyMerge(
 	wtreeset map[string]bool,
 	allowOutsideWorktree bool,
@@ -163,6 +271,7 @@ func TallyCommitsApplyMerge(
 			bt := union[k]
 
 			at.Commits += bt.Commits
+			at.FirstCommitTime = minTime(at.FirstCommitTime, bt.FirstCommitTime)
 			at.LastCommitTime = timeutils.Max(
 				at.LastCommitTime,
 				bt.LastCommitTime,
@@ -211,7 +320,16 @@ func TallyCommitsDiffApplyMerge(
 	}
 
 	finalize := func(authors map[string]AuthorPaths) []Tally {
-		tallies := sumOverPaths(authors, wtreeset, allowOutsideWorktree)
+		var survivingByPath map[string]blameTally
+		if opts.Mode == SurvivingLinesMode {
+			var err error
+			survivingByPath, err = blameSurvivingLines(wtreeset, opts)
+			if err != nil {
+				logger().Error("failed to compute surviving lines", "err", err)
+			}
+		}
+
+		tallies := sumOverPaths(authors, wtreeset, allowOutsideWorktree, survivingByPath)
 		return sortTallies(tallies, opts.Mode)
 	}
 
@@ -232,23 +350,55 @@ func tallyCommits(
 	if !opts.IsDiffMode() && allowOutsideWorktree {
 		authorTallies = map[string]Tally{}
 
+		// Fractional commit credit per author, per opts.CoAuthorWeight.
+		// Summed as a float and rounded once at the end so Equal actually
+		// comes out fractional per co-author instead of collapsing to the
+		// same whole-commit credit as FullToEach.
+		commitCredit := map[string]float64{}
+
+		// Days each author committed on, for ActiveDays / LongestStreakDays
+		activeDays := map[string]map[string]bool{}
+
 		// Just sum over commits
 		for commit, err := range commits {
 			if err != nil {
 				return nil, fmt.Errorf("error iterating commits: %w", err)
 			}
 
-			key := opts.Key(commit)
+			for _, share := range creditShares(commit, opts) {
+				authorTally := authorTallies[share.key]
+				authorTally.AuthorName = share.name
+				authorTally.AuthorEmail = share.email
+				authorTally.FirstCommitTime = minTime(
+					commit.Date,
+					authorTally.FirstCommitTime,
+				)
+				authorTally.LastCommitTime = timeutils.Max(
+					commit.Date,
+					authorTally.LastCommitTime,
+				)
+
+				authorTallies[share.key] = authorTally
+				commitCredit[share.key] += share.weight
+
+				days, ok := activeDays[share.key]
+				if !ok {
+					days = map[string]bool{}
+					activeDays[share.key] = days
+				}
+				days[dayKey(commit.Date, opts.TZ)] = true
+			}
+		}
 
+		for key, credit := range commitCredit {
 			authorTally := authorTallies[key]
-			authorTally.AuthorName = commit.AuthorName
-			authorTally.AuthorEmail = commit.AuthorEmail
-			authorTally.Commits += 1
-			authorTally.LastCommitTime = timeutils.Max(
-				commit.Date,
-				authorTally.LastCommitTime,
-			)
+			authorTally.Commits = int(math.Round(credit))
+			authorTallies[key] = authorTally
+		}
 
+		for key, days := range activeDays {
+			authorTally := authorTallies[key]
+			authorTally.ActiveDays, authorTally.LongestStreakDays = streakStats(days)
 			authorTallies[key] = authorTally
 		}
 	} else {
@@ -257,10 +407,22 @@ func tallyCommits(
 			return nil, err
 		}
 
+		var survivingByPath map[string]blameTally
+		if opts.Mode == SurvivingLinesMode {
+			survivingByPath, err = blameSurvivingLines(
+				wtreefiles,
+				opts,
+			)
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		authorTallies = sumOverPaths(
 			pathTallies,
 			wtreefiles,
 			allowOutsideWorktree,
+			survivingByPath,
 		)
 	}
 
@@ -278,10 +440,14 @@ func sortTallies(tallies map[string]Tally, mode TallyMode) []Tally {
 	return sorted
 }
 
+// survivingByPath, if non-nil, maps each worktree path to a blameTally of
+// lines currently attributed to each author (see blame.go). Pass nil for
+// tally modes that don't need it.
 func sumOverPaths(
 	authors map[string]AuthorPaths,
 	wtreefiles map[string]bool,
 	allowOutsideWorktree bool,
+	survivingByPath map[string]blameTally,
 ) map[string]Tally {
 	authorTallies := map[string]Tally{}
 
@@ -294,6 +460,10 @@ func sumOverPaths(
 		for path, pathTally := range author.paths {
 			if inWTree := wtreefiles[path]; inWTree || allowOutsideWorktree {
 				runningTally = runningTally.Add(pathTally)
+
+				if blame, ok := survivingByPath[path]; ok {
+					runningTally.surviving += blame[key]
+				}
 			}
 		}
 
@@ -301,6 +471,10 @@ func sumOverPaths(
 		authorTally.LinesAdded = runningTally.added
 		authorTally.LinesRemoved = runningTally.removed
 		authorTally.FileCount = runningTally.numTallied
+		authorTally.LinesSurviving = runningTally.surviving
+		authorTally.LinesChurned = authorTally.LinesAdded - authorTally.LinesSurviving
+		authorTally.ActiveDays, authorTally.LongestStreakDays = streakStats(runningTally.days)
+		authorTally.FirstCommitTime = runningTally.firstCommitTime
 		authorTally.LastCommitTime = runningTally.lastCommitTime
 
 		authorTallies[key] = authorTally